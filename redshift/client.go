@@ -0,0 +1,216 @@
+package redshift
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/lib/pq"
+)
+
+const (
+	defaultRetryMaxAttempts = 5
+	defaultRetryBaseDelay   = 500 * time.Millisecond
+	defaultRetryMaxDelay    = 10 * time.Second
+)
+
+// Client wraps the underlying connection to the Redshift cluster along with
+// the retry policy applied by WithRetry.
+type Client struct {
+	db *sql.DB
+
+	retryMaxAttempts int
+	retryBaseDelay   time.Duration
+	retryMaxDelay    time.Duration
+}
+
+// ExecQueryer is satisfied by both *sql.DB and *sql.Tx, letting resource CRUD
+// code run the same statements against either a plain connection or a
+// transaction.
+type ExecQueryer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// isRetryableError reports whether err looks like a transient Redshift
+// error worth retrying: serialization failures or connection resets. It
+// deliberately does not treat sql.ErrNoRows as retryable - a zero-row
+// result is a legitimate outcome for most callers of the shared
+// WithRetry/WithRetryTx primitive, not just the post-create oid lookups.
+// Callers that need to poll for eventually-consistent metadata (e.g.
+// waitForSchemaVisible) should do so explicitly rather than relying on
+// this classifier.
+func isRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "40001" { // serialization_failure
+		return true
+	}
+
+	msg := err.Error()
+	return strings.Contains(msg, "connection reset by peer") || strings.Contains(msg, "broken pipe")
+}
+
+// retryPolicy resolves the client's effective max attempts / base delay /
+// max delay, falling back to the package defaults for any field the client
+// wasn't configured with.
+func (c *Client) retryPolicy() (maxAttempts int, baseDelay, maxDelay time.Duration) {
+	maxAttempts = c.retryMaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultRetryMaxAttempts
+	}
+	baseDelay = c.retryBaseDelay
+	if baseDelay <= 0 {
+		baseDelay = defaultRetryBaseDelay
+	}
+	maxDelay = c.retryMaxDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultRetryMaxDelay
+	}
+	return maxAttempts, baseDelay, maxDelay
+}
+
+// backoffDelay computes the delay before a given retry attempt (0-indexed),
+// doubling from baseDelay and capped at maxDelay.
+func backoffDelay(baseDelay, maxDelay time.Duration, attempt int) time.Duration {
+	return time.Duration(math.Min(float64(maxDelay), float64(baseDelay)*math.Pow(2, float64(attempt))))
+}
+
+// WithRetry runs fn against the client's connection, retrying with bounded
+// exponential backoff on Redshift-transient errors.
+func (c *Client) WithRetry(ctx context.Context, fn func(ExecQueryer) error) error {
+	maxAttempts, baseDelay, maxDelay := c.retryPolicy()
+
+	var err error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if err = fn(c.db); err == nil {
+			return nil
+		}
+		if !isRetryableError(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoffDelay(baseDelay, maxDelay, attempt)):
+		}
+	}
+
+	return errwrap.Wrapf("Error after exhausting retries: {{err}}", err)
+}
+
+// WithRetryTx runs fn inside a fresh transaction, retrying the whole
+// transaction with bounded exponential backoff on Redshift-transient
+// errors. fn must not commit or roll back tx itself.
+func (c *Client) WithRetryTx(ctx context.Context, fn func(*sql.Tx) error) error {
+	maxAttempts, baseDelay, maxDelay := c.retryPolicy()
+
+	var err error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if err = c.runTx(fn); err == nil {
+			return nil
+		}
+		if !isRetryableError(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoffDelay(baseDelay, maxDelay, attempt)):
+		}
+	}
+
+	return errwrap.Wrapf("Error after exhausting retries: {{err}}", err)
+}
+
+// runTx begins a transaction and runs fn against it, committing on success
+// and rolling back if fn or the commit itself fails.
+func (c *Client) runTx(fn func(*sql.Tx) error) (err error) {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return errwrap.Wrapf("Error starting transaction: {{err}}", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// sessionConnector wraps a pq.Connector so that every physical connection
+// the pool opens - not just whichever one db.Exec happens to grab once at
+// startup - has per-session settings like statement_timeout applied. A
+// plain db.Exec only reaches one arbitrary pooled connection, so it misses
+// every connection opened later (additional pool growth, reconnects after
+// idle eviction).
+type sessionConnector struct {
+	driver.Connector
+	statementTimeoutMS int
+}
+
+func (c *sessionConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.statementTimeoutMS <= 0 {
+		return conn, nil
+	}
+
+	execer, ok := conn.(driver.ExecerContext)
+	if !ok {
+		conn.Close()
+		return nil, fmt.Errorf("redshift: connection does not support setting statement_timeout")
+	}
+
+	stmt := fmt.Sprintf("SET statement_timeout = %d", c.statementTimeoutMS)
+	if _, err := execer.ExecContext(ctx, stmt, nil); err != nil {
+		conn.Close()
+		return nil, errwrap.Wrapf("Error applying statement_timeout to new connection: {{err}}", err)
+	}
+
+	return conn, nil
+}
+
+// waitForSchemaVisible polls pg_namespace for schemaName's oid until it
+// becomes visible - metadata for a newly created external schema does not
+// propagate instantly - or until deadline elapses.
+func waitForSchemaVisible(ctx context.Context, db ExecQueryer, schemaName string, deadline time.Time) (string, error) {
+	for {
+		var oid string
+		err := db.QueryRow("SELECT oid FROM pg_namespace WHERE nspname = $1", schemaName).Scan(&oid)
+		switch {
+		case err == nil:
+			return oid, nil
+		case err != sql.ErrNoRows:
+			return "", errwrap.Wrapf(fmt.Sprintf("Error polling for schema %s: {{err}}", schemaName), err)
+		}
+
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("timed out waiting for schema %s to become visible", schemaName)
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(250 * time.Millisecond):
+		}
+	}
+}
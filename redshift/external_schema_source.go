@@ -0,0 +1,270 @@
+package redshift
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bitzstein/terraform-provider-redshift/redshift/sqlutil"
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// externalSchemaSource captures the DDL-building and state-reconstruction
+// differences between the backends `CREATE EXTERNAL SCHEMA` can front -
+// AWS Glue Data Catalog, Hive Metastore, and federated RDS/Redshift
+// databases. Each implementation owns exactly one nested config block on
+// `redshift_external_schema`.
+type externalSchemaSource interface {
+	// fromClause builds the `FROM ...` portion of the CREATE EXTERNAL SCHEMA
+	// statement from this source's nested block.
+	fromClause(d *schema.ResourceData) (string, error)
+
+	// read populates this source's nested block from the esoptions JSON
+	// blob stored against the schema in svv_external_schemas.
+	read(d *schema.ResourceData, esoptions string) error
+}
+
+// externalSchemaSources maps the `source_type` discriminator to the
+// strategy that knows how to build and read that source's DDL.
+var externalSchemaSources = map[string]externalSchemaSource{
+	"data_catalog":   dataCatalogSource{},
+	"hive_metastore": hiveMetastoreSource{},
+	"postgres":       federatedRDSSource{keyword: "POSTGRES", includeSchema: true},
+	"mysql":          federatedRDSSource{keyword: "MYSQL", includeSchema: false},
+	"redshift":       federatedRedshiftSource{},
+}
+
+// eskindToSourceType maps svv_external_schemas.eskind values back to the
+// source_type discriminator used in configuration.
+var eskindToSourceType = map[string]string{
+	"GLUE":     "data_catalog",
+	"HIVE":     "hive_metastore",
+	"POSTGRES": "postgres",
+	"MYSQL":    "mysql",
+	"REDSHIFT": "redshift",
+}
+
+func sourceTypeForEskind(eskind string) (string, error) {
+	sourceType, ok := eskindToSourceType[eskind]
+	if !ok {
+		return "", fmt.Errorf("unrecognized external schema kind %q", eskind)
+	}
+	return sourceType, nil
+}
+
+// firstBlock returns the sole element of a MaxItems-1 TypeList block, or nil
+// if the block was not set.
+func firstBlock(d *schema.ResourceData, key string) map[string]interface{} {
+	list := d.Get(key).([]interface{})
+	if len(list) == 0 || list[0] == nil {
+		return nil
+	}
+	return list[0].(map[string]interface{})
+}
+
+type dataCatalogSource struct{}
+
+// dataCatalogFromClause builds the `FROM DATA CATALOG ...` clause shared by
+// both redshift_external_schema (source_type = "data_catalog") and the
+// legacy redshift_external_schema_data_catalog resource, so the two never
+// drift out of sync.
+func dataCatalogFromClause(databaseName, iamRole string) string {
+	return fmt.Sprintf(
+		"DATA CATALOG DATABASE %s IAM_ROLE %s",
+		sqlutil.QuoteLiteral(databaseName),
+		sqlutil.QuoteLiteral(iamRole),
+	)
+}
+
+// dataCatalogOptionsFromEsoptions extracts the Data Catalog database name
+// and IAM role out of a schema's esoptions JSON, shared by both resources
+// that front AWS Glue Data Catalog external schemas.
+func dataCatalogOptionsFromEsoptions(esoptions string) (databaseName, iamRole string, err error) {
+	opts, err := parseEsoptions(esoptions)
+	if err != nil {
+		return "", "", err
+	}
+	return stringOpt(opts, "DATABASE"), stringOpt(opts, "IAM_ROLE"), nil
+}
+
+func (dataCatalogSource) fromClause(d *schema.ResourceData) (string, error) {
+	block := firstBlock(d, "data_catalog")
+	if block == nil {
+		return "", fmt.Errorf("source_type \"data_catalog\" requires a data_catalog block")
+	}
+
+	return dataCatalogFromClause(block["database_name"].(string), block["iam_role"].(string)), nil
+}
+
+func (dataCatalogSource) read(d *schema.ResourceData, esoptions string) error {
+	databaseName, iamRole, err := dataCatalogOptionsFromEsoptions(esoptions)
+	if err != nil {
+		return err
+	}
+
+	return d.Set("data_catalog", []interface{}{map[string]interface{}{
+		"database_name": databaseName,
+		"iam_role":      iamRole,
+	}})
+}
+
+type hiveMetastoreSource struct{}
+
+func (hiveMetastoreSource) fromClause(d *schema.ResourceData) (string, error) {
+	block := firstBlock(d, "hive_metastore")
+	if block == nil {
+		return "", fmt.Errorf("source_type \"hive_metastore\" requires a hive_metastore block")
+	}
+
+	clause := fmt.Sprintf("HIVE METASTORE URI %s", sqlutil.QuoteLiteral(block["uri"].(string)))
+	if port, ok := block["port"].(int); ok && port != 0 {
+		clause += fmt.Sprintf(" PORT %d", port)
+	}
+
+	return clause, nil
+}
+
+func (hiveMetastoreSource) read(d *schema.ResourceData, esoptions string) error {
+	opts, err := parseEsoptions(esoptions)
+	if err != nil {
+		return err
+	}
+
+	block := map[string]interface{}{"uri": stringOpt(opts, "URI")}
+	port, ok, err := intOpt(opts, "PORT")
+	if err != nil {
+		return err
+	}
+	if ok {
+		block["port"] = port
+	}
+
+	return d.Set("hive_metastore", []interface{}{block})
+}
+
+// federatedRDSSource handles both the POSTGRES and MYSQL federated query
+// sources, which share the same nested block (`federated_rds`) but differ
+// in keyword and in whether a SCHEMA clause applies.
+type federatedRDSSource struct {
+	keyword       string
+	includeSchema bool
+}
+
+func (s federatedRDSSource) fromClause(d *schema.ResourceData) (string, error) {
+	block := firstBlock(d, "federated_rds")
+	if block == nil {
+		return "", fmt.Errorf("source_type %q requires a federated_rds block", strings.ToLower(s.keyword))
+	}
+
+	clause := fmt.Sprintf("%s DATABASE %s", s.keyword, sqlutil.QuoteLiteral(block["database"].(string)))
+
+	if s.includeSchema {
+		if schemaName, ok := block["schema"].(string); ok && schemaName != "" {
+			clause += fmt.Sprintf(" SCHEMA %s", sqlutil.QuoteLiteral(schemaName))
+		}
+	}
+
+	clause += fmt.Sprintf(" URI %s", sqlutil.QuoteLiteral(block["uri"].(string)))
+
+	if iamRole, ok := block["iam_role"].(string); ok && iamRole != "" {
+		clause += fmt.Sprintf(" IAM_ROLE %s", sqlutil.QuoteLiteral(iamRole))
+	}
+	if secretArn, ok := block["secret_arn"].(string); ok && secretArn != "" {
+		clause += fmt.Sprintf(" SECRET_ARN %s", sqlutil.QuoteLiteral(secretArn))
+	}
+
+	return clause, nil
+}
+
+func (s federatedRDSSource) read(d *schema.ResourceData, esoptions string) error {
+	opts, err := parseEsoptions(esoptions)
+	if err != nil {
+		return err
+	}
+
+	return d.Set("federated_rds", []interface{}{map[string]interface{}{
+		"database":   stringOpt(opts, "DATABASE"),
+		"schema":     stringOpt(opts, "SCHEMA"),
+		"uri":        stringOpt(opts, "URI"),
+		"iam_role":   stringOpt(opts, "IAM_ROLE"),
+		"secret_arn": stringOpt(opts, "SECRET_ARN"),
+	}})
+}
+
+type federatedRedshiftSource struct{}
+
+func (federatedRedshiftSource) fromClause(d *schema.ResourceData) (string, error) {
+	block := firstBlock(d, "federated_redshift")
+	if block == nil {
+		return "", fmt.Errorf("source_type \"redshift\" requires a federated_redshift block")
+	}
+
+	return fmt.Sprintf(
+		"REDSHIFT DATABASE %s SCHEMA %s",
+		sqlutil.QuoteLiteral(block["database"].(string)),
+		sqlutil.QuoteLiteral(block["schema"].(string)),
+	), nil
+}
+
+func (federatedRedshiftSource) read(d *schema.ResourceData, esoptions string) error {
+	opts, err := parseEsoptions(esoptions)
+	if err != nil {
+		return err
+	}
+
+	return d.Set("federated_redshift", []interface{}{map[string]interface{}{
+		"database": stringOpt(opts, "DATABASE"),
+		"schema":   stringOpt(opts, "SCHEMA"),
+	}})
+}
+
+// parseEsoptions decodes the esoptions JSON blob Redshift stores against an
+// external schema. Values are not assumed to be strings - esoptions.PORT,
+// for instance, is a JSON number - so callers must extract fields with
+// stringOpt/intOpt rather than asserting directly.
+func parseEsoptions(esoptions string) (map[string]interface{}, error) {
+	var opts map[string]interface{}
+	if err := json.Unmarshal([]byte(esoptions), &opts); err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("Error parsing esoptions %q: {{err}}", esoptions), err)
+	}
+	return opts, nil
+}
+
+// stringOpt reads a string-valued field out of a parsed esoptions map,
+// tolerating absent/null values (returned as "").
+func stringOpt(opts map[string]interface{}, key string) string {
+	v, ok := opts[key]
+	if !ok || v == nil {
+		return ""
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// intOpt reads an integer-valued field out of a parsed esoptions map. JSON
+// numbers decode as float64, but some Redshift esoptions represent numbers
+// as strings, so both are accepted. The second return value reports whether
+// the key was present at all.
+func intOpt(opts map[string]interface{}, key string) (int, bool, error) {
+	v, ok := opts[key]
+	if !ok || v == nil {
+		return 0, false, nil
+	}
+
+	switch t := v.(type) {
+	case float64:
+		return int(t), true, nil
+	case string:
+		n, err := strconv.Atoi(t)
+		if err != nil {
+			return 0, false, fmt.Errorf("invalid %s in esoptions: %s", key, t)
+		}
+		return n, true, nil
+	default:
+		return 0, false, fmt.Errorf("unexpected type for %s in esoptions: %T", key, v)
+	}
+}
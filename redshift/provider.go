@@ -0,0 +1,130 @@
+package redshift
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/lib/pq"
+)
+
+// Provider returns the schema.Provider for Redshift, exposing connection
+// settings plus every resource this module manages.
+func Provider() *schema.Provider {
+	return &schema.Provider{
+		Schema: map[string]*schema.Schema{
+			"host": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of Redshift server address to connect to",
+			},
+			"port": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     5439,
+				Description: "The Redshift port number to connect to",
+			},
+			"username": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Username to authenticate with Redshift",
+			},
+			"password": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Sensitive:   true,
+				Description: "Password to authenticate with Redshift",
+			},
+			"database": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "dev",
+				Description: "Database to connect to",
+			},
+			"sslmode": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "require",
+				Description: "Connection sslmode, passed through to lib/pq",
+			},
+			"max_connections": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     10,
+				Description: "Maximum number of open connections to the Redshift cluster",
+			},
+			"connect_timeout": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     15,
+				Description: "Maximum time, in seconds, to wait for a connection to Redshift to be established",
+			},
+			"statement_timeout": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     0,
+				Description: "Per-session statement_timeout, in milliseconds. 0 leaves the cluster default in place.",
+			},
+			"max_retries": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     defaultRetryMaxAttempts,
+				Description: "Maximum number of attempts when retrying a Redshift-transient error on a Create/Update",
+			},
+			"retry_base_delay_ms": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     int(defaultRetryBaseDelay / time.Millisecond),
+				Description: "Initial delay, in milliseconds, before the first retry. Doubles on each subsequent attempt.",
+			},
+			"retry_max_delay_ms": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     int(defaultRetryMaxDelay / time.Millisecond),
+				Description: "Maximum delay, in milliseconds, between retries",
+			},
+		},
+
+		ResourcesMap: map[string]*schema.Resource{
+			"redshift_external_schema_data_catalog": redshiftExternalSchemaDataCatalog(),
+			"redshift_external_schema":              redshiftExternalSchema(),
+			"redshift_schema":                       redshiftSchema(),
+		},
+
+		ConfigureFunc: providerConfigure,
+	}
+}
+
+func providerConfigure(d *schema.ResourceData) (interface{}, error) {
+	conninfo := fmt.Sprintf(
+		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s connect_timeout=%d",
+		d.Get("host").(string),
+		d.Get("port").(int),
+		d.Get("username").(string),
+		d.Get("password").(string),
+		d.Get("database").(string),
+		d.Get("sslmode").(string),
+		d.Get("connect_timeout").(int),
+	)
+
+	connector, err := pq.NewConnector(conninfo)
+	if err != nil {
+		return nil, errwrap.Wrapf("Error connecting to Redshift: {{err}}", err)
+	}
+
+	db := sql.OpenDB(&sessionConnector{
+		Connector:          connector,
+		statementTimeoutMS: d.Get("statement_timeout").(int),
+	})
+
+	db.SetMaxOpenConns(d.Get("max_connections").(int))
+
+	return &Client{
+		db:               db,
+		retryMaxAttempts: d.Get("max_retries").(int),
+		retryBaseDelay:   time.Duration(d.Get("retry_base_delay_ms").(int)) * time.Millisecond,
+		retryMaxDelay:    time.Duration(d.Get("retry_max_delay_ms").(int)) * time.Millisecond,
+	}, nil
+}
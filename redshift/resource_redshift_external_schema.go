@@ -0,0 +1,382 @@
+package redshift
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bitzstein/terraform-provider-redshift/redshift/sqlutil"
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+// redshiftExternalSchema is the polymorphic successor to
+// redshiftExternalSchemaDataCatalog: it covers every backend Redshift's
+// `CREATE EXTERNAL SCHEMA` can front, selected via `source_type`.
+func redshiftExternalSchema() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceRedshiftExternalSchemaCreate,
+		Read:   resourceRedshiftExternalSchemaRead,
+		Update: resourceRedshiftExternalSchemaUpdate,
+		Delete: resourceRedshiftExternalSchemaDelete,
+		Exists: resourceRedshiftExternalSchemaExists,
+		Importer: &schema.ResourceImporter{
+			State: resourceRedshiftExternalSchemaImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"schema_name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The name of the external schema in Redshift",
+			},
+			"source_type": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				Description:  "The kind of external source this schema is backed by. One of data_catalog, hive_metastore, postgres, mysql, redshift.",
+				ValidateFunc: validation.StringInSlice([]string{"data_catalog", "hive_metastore", "postgres", "mysql", "redshift"}, false),
+			},
+			"data_catalog": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				ForceNew:    true,
+				MaxItems:    1,
+				Description: "Configuration when source_type is data_catalog",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"database_name": {
+							Type:        schema.TypeString,
+							Required:    true,
+							ForceNew:    true,
+							Description: "The name of the database in the AWS Glue Data Catalog",
+						},
+						"iam_role": {
+							Type:        schema.TypeString,
+							Required:    true,
+							ForceNew:    true,
+							Description: "The ARN of the IAM role with S3 and AWS Glue Data Catalog access permissions",
+						},
+					},
+				},
+			},
+			"hive_metastore": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				ForceNew:    true,
+				MaxItems:    1,
+				Description: "Configuration when source_type is hive_metastore",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"uri": {
+							Type:        schema.TypeString,
+							Required:    true,
+							ForceNew:    true,
+							Description: "The URI of the Hive Metastore host",
+						},
+						"port": {
+							Type:        schema.TypeInt,
+							Optional:    true,
+							ForceNew:    true,
+							Description: "The port number of the Hive Metastore host. Defaults to 9083.",
+						},
+					},
+				},
+			},
+			"federated_rds": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				ForceNew:    true,
+				MaxItems:    1,
+				Description: "Configuration when source_type is postgres or mysql",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"database": {
+							Type:        schema.TypeString,
+							Required:    true,
+							ForceNew:    true,
+							Description: "The name of the RDS database",
+						},
+						"schema": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							ForceNew:    true,
+							Description: "The name of the schema within the RDS database. Only applies to postgres.",
+						},
+						"uri": {
+							Type:        schema.TypeString,
+							Required:    true,
+							ForceNew:    true,
+							Description: "The URI of the RDS host",
+						},
+						"iam_role": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							ForceNew:    true,
+							Description: "The ARN of the IAM role used to access Secrets Manager and the RDS instance",
+						},
+						"secret_arn": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							ForceNew:    true,
+							Description: "The ARN of the Secrets Manager secret holding the RDS credentials",
+						},
+					},
+				},
+			},
+			"federated_redshift": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				ForceNew:    true,
+				MaxItems:    1,
+				Description: "Configuration when source_type is redshift",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"database": {
+							Type:        schema.TypeString,
+							Required:    true,
+							ForceNew:    true,
+							Description: "The name of the database on the remote Redshift cluster",
+						},
+						"schema": {
+							Type:        schema.TypeString,
+							Required:    true,
+							ForceNew:    true,
+							Description: "The name of the schema on the remote Redshift cluster",
+						},
+					},
+				},
+			},
+			"owner": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Computed:    true,
+				Description: "Defaults to user specified in provider",
+			},
+			"cascade_on_delete": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Keyword that indicates to automatically drop all objects in the schema, such as tables and functions. By default it doesn't for your safety",
+				Default:     false,
+			},
+			"policy": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "Policy to apply to the schema, supersedes any existing grants",
+				Elem:        schemaPolicyElem(),
+			},
+		},
+	}
+}
+
+func sourceForResource(d *schema.ResourceData) (externalSchemaSource, error) {
+	sourceType := d.Get("source_type").(string)
+	source, ok := externalSchemaSources[sourceType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported source_type %q", sourceType)
+	}
+	return source, nil
+}
+
+func resourceRedshiftExternalSchemaExists(d *schema.ResourceData, meta interface{}) (bool, error) {
+	client := meta.(*Client).db
+
+	var name string
+
+	err := client.QueryRow("SELECT nspname FROM pg_namespace WHERE oid = $1", d.Id()).Scan(&name)
+	switch {
+	case err == sql.ErrNoRows:
+		return false, nil
+	case err != nil:
+		return false, errwrap.Wrapf(fmt.Sprintf("Error reading external schema with oid %s: {{err}}", d.Id()), err)
+	}
+	return true, nil
+}
+
+func resourceRedshiftExternalSchemaCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client)
+	redshiftClient := client.db
+
+	source, err := sourceForResource(d)
+	if err != nil {
+		return err
+	}
+
+	fromClause, err := source.fromClause(d)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("CREATE EXTERNAL SCHEMA ")
+	buf.WriteString(sqlutil.QuoteIdentifier(d.Get("schema_name").(string)))
+	buf.WriteString(" FROM ")
+	buf.WriteString(fromClause)
+
+	createStatement := buf.String()
+
+	log.Printf("Create external schema statement: %s", createStatement)
+
+	if err := client.WithRetry(context.Background(), func(q ExecQueryer) error {
+		_, err := q.Exec(createStatement)
+		return err
+	}); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error creating external schema %s: {{err}}", d.Get("schema_name").(string)), err)
+	}
+
+	// The changes do not propagate instantly; poll pg_namespace until the
+	// new schema becomes visible instead of blindly sleeping for it.
+	oid, err := waitForSchemaVisible(context.Background(), redshiftClient, d.Get("schema_name").(string), time.Now().Add(30*time.Second))
+	if err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error reading oid for created external schema %s: {{err}}", d.Get("schema_name").(string)), err)
+	}
+
+	if _, ok := d.GetOk("owner"); ok {
+		if err := updateExternalSchemaOwner(d, redshiftClient); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Created external schema with oid: %s", oid)
+
+	d.SetId(oid)
+
+	if v, ok := d.GetOk("policy"); ok {
+		if err := applySchemaPolicies(redshiftClient, d.Get("schema_name").(string), nil, expandSchemaPolicies(v)); err != nil {
+			return err
+		}
+	}
+
+	return readRedshiftExternalSchema(d, redshiftClient)
+}
+
+func resourceRedshiftExternalSchemaRead(d *schema.ResourceData, meta interface{}) error {
+	redshiftClient := meta.(*Client).db
+	return readRedshiftExternalSchema(d, redshiftClient)
+}
+
+func readRedshiftExternalSchema(d *schema.ResourceData, db *sql.DB) error {
+	var (
+		schemaName string
+		owner      int
+		eskind     string
+		esoptions  string
+	)
+
+	err := db.QueryRow(
+		"SELECT nspname, nspowner, eskind, esoptions FROM pg_namespace JOIN svv_external_schemas ON pg_namespace.oid = esoid WHERE pg_namespace.oid = $1",
+		d.Id(),
+	).Scan(&schemaName, &owner, &eskind, &esoptions)
+	if err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error reading external schema information for oid %s: {{err}}", d.Id()), err)
+	}
+
+	sourceType, err := sourceTypeForEskind(eskind)
+	if err != nil {
+		return err
+	}
+
+	d.Set("schema_name", schemaName)
+	d.Set("owner", owner)
+	d.Set("source_type", sourceType)
+
+	if err := externalSchemaSources[sourceType].read(d, esoptions); err != nil {
+		return err
+	}
+
+	policies, err := readSchemaPolicies(db, schemaName)
+	if err != nil {
+		return err
+	}
+	d.Set("policy", flattenSchemaPolicies(policies))
+
+	return nil
+}
+
+func updateExternalSchemaOwner(d *schema.ResourceData, q ExecQueryer) error {
+	username := GetUsersnamesForUsesysid(q, []interface{}{d.Get("owner").(int)})
+
+	var buf bytes.Buffer
+	buf.WriteString("ALTER SCHEMA ")
+	buf.WriteString(sqlutil.QuoteIdentifier(d.Get("schema_name").(string)))
+	buf.WriteString(" OWNER TO ")
+	buf.WriteString(quoteRole(username[0]))
+
+	if _, err := q.Exec(buf.String()); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error updating external schema %s owner to %s: {{err}}", d.Get("schema_name").(string), username[0]), err)
+	}
+
+	return nil
+}
+
+func updateRedshiftExternalSchema(d *schema.ResourceData, tx ExecQueryer) error {
+	if d.HasChange("schema_name") {
+		oldName, newName := d.GetChange("schema_name")
+
+		var buf bytes.Buffer
+		buf.WriteString("ALTER SCHEMA ")
+		buf.WriteString(sqlutil.QuoteIdentifier(oldName.(string)))
+		buf.WriteString(" RENAME TO ")
+		buf.WriteString(sqlutil.QuoteIdentifier(newName.(string)))
+
+		if _, err := tx.Exec(buf.String()); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("Error renaming external schema %s to %s: {{err}}", oldName.(string), newName.(string)), err)
+		}
+	}
+
+	if d.HasChange("owner") {
+		if err := updateExternalSchemaOwner(d, tx); err != nil {
+			return err
+		}
+	}
+
+	if d.HasChange("policy") {
+		oldPolicies, newPolicies := d.GetChange("policy")
+		if err := applySchemaPolicies(tx, d.Get("schema_name").(string), expandSchemaPolicies(oldPolicies), expandSchemaPolicies(newPolicies)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func resourceRedshiftExternalSchemaUpdate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client)
+
+	if err := client.WithRetryTx(context.Background(), func(tx *sql.Tx) error {
+		return updateRedshiftExternalSchema(d, tx)
+	}); err != nil {
+		return err
+	}
+
+	return readRedshiftExternalSchema(d, client.db)
+}
+
+func resourceRedshiftExternalSchemaDelete(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client).db
+
+	var buf bytes.Buffer
+	buf.WriteString("DROP SCHEMA ")
+	buf.WriteString(sqlutil.QuoteIdentifier(d.Get("schema_name").(string)))
+
+	if v, ok := d.GetOk("cascade_on_delete"); ok && v.(bool) {
+		buf.WriteString(" CASCADE")
+	}
+
+	if _, err := client.Exec(buf.String()); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error dropping external schema %s: {{err}}", d.Get("schema_name").(string)), err)
+	}
+
+	return nil
+}
+
+func resourceRedshiftExternalSchemaImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	if err := resourceRedshiftExternalSchemaRead(d, meta); err != nil {
+		return nil, err
+	}
+	return []*schema.ResourceData{d}, nil
+}
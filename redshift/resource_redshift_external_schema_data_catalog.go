@@ -1,14 +1,20 @@
 package redshift
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"github.com/hashicorp/errwrap"
+
 	"github.com/hashicorp/terraform/helper/schema"
-	"log"
-	"time"
 )
 
+// redshiftExternalSchemaDataCatalog is kept as a dedicated, narrower
+// resource for backward compatibility with existing configurations; new
+// configurations should prefer redshiftExternalSchema with
+// source_type = "data_catalog", which also supports Hive Metastore and
+// federated RDS/Redshift sources. Its CRUD functions are a thin wrapper
+// around resourceRedshiftExternalSchema*'s shared logic so the two never
+// drift out of sync.
 func redshiftExternalSchemaDataCatalog() *schema.Resource {
 	return &schema.Resource{
 		Create: resourceDataCatalogSchemaCreate,
@@ -50,170 +56,96 @@ func redshiftExternalSchemaDataCatalog() *schema.Resource {
 				Description: "Keyword that indicates to automatically drop all objects in the schema, such as tables and functions. By default it doesn't for your safety",
 				Default:     false,
 			},
+			"policy": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "Policy to apply to the schema, supersedes any existing grants",
+				Elem:        schemaPolicyElem(),
+			},
 		},
 	}
 }
 
-func resourceDataCatalogSchemaExists(d *schema.ResourceData, meta interface{}) (b bool, e error) {
-	// Exists - This is called to verify a resource still exists. It is called prior to Read,
-	// and lowers the burden of Read to be able to assume the resource exists.
-	client := meta.(*Client).db
-
-	var name string
-
-	var existenceQuery = "SELECT nspname FROM pg_namespace WHERE oid = $1"
-
-	log.Printf("Does external schema exist query: %s, %d", existenceQuery, d.Id())
-
-	err := client.QueryRow(existenceQuery, d.Id()).Scan(&name)
-	switch {
-	case err == sql.ErrNoRows:
-		return false, nil
-	case err != nil:
-		return false, errwrap.Wrapf(fmt.Sprintf("Error reading external schema with oid %d: {{err}}", d.Id() ), err)		
-	}
-	return true, nil
+// dataCatalogWrapperData builds a *schema.ResourceData against the
+// polymorphic redshift_external_schema resource's schema, translating this
+// legacy resource's flat schema_name/database_name/iam_role into the
+// source_type = "data_catalog" shape resourceRedshiftExternalSchema*
+// expects, so CRUD logic lives in exactly one place.
+func dataCatalogWrapperData(d *schema.ResourceData) *schema.ResourceData {
+	wrapper := redshiftExternalSchema().Data(nil)
+	wrapper.SetId(d.Id())
+	wrapper.Set("schema_name", d.Get("schema_name"))
+	wrapper.Set("source_type", "data_catalog")
+	wrapper.Set("data_catalog", []interface{}{map[string]interface{}{
+		"database_name": d.Get("database_name"),
+		"iam_role":      d.Get("iam_role"),
+	}})
+	wrapper.Set("owner", d.Get("owner"))
+	wrapper.Set("cascade_on_delete", d.Get("cascade_on_delete"))
+	wrapper.Set("policy", d.Get("policy"))
+	return wrapper
 }
 
-func resourceDataCatalogSchemaCreate(d *schema.ResourceData, meta interface{}) error {
-
-	redshiftClient := meta.(*Client).db
-
-	var createStatement string = "CREATE EXTERNAL SCHEMA " + d.Get("schema_name").(string) + " FROM DATA CATALOG DATABASE '" + d.Get("database_name").(string) + "' IAM_ROLE '" + d.Get("iam_role").(string) + "'"
-
-	log.Printf("Create external schema statement: %s", createStatement)
-
-	if _, err := redshiftClient.Exec(createStatement); err != nil {
-		return errwrap.Wrapf(fmt.Sprintf("Error creating external schema %s: {{err}}", d.Get("schema_name").(string)), err)
-	}
-
-	//The changes do not propagate instantly
-	time.Sleep(5 * time.Second)
-
-	//If owner was specified, apply it through ALTER SCHEMA
-	if _, ok := d.GetOk("owner"); ok {
-		if err := updateDataCatalogSchemaOwner(d, redshiftClient); err != nil {
-			return err
-		}
+// syncDataCatalogFromWrapper copies the fields resourceRedshiftExternalSchema*
+// populated on wrapper back onto this resource's own flat schema.
+func syncDataCatalogFromWrapper(d, wrapper *schema.ResourceData) error {
+	block := firstBlock(wrapper, "data_catalog")
+	if block == nil {
+		return fmt.Errorf("expected a data_catalog block reading external schema %s", wrapper.Get("schema_name"))
 	}
 
-	var oid string
-
-	err := redshiftClient.QueryRow("SELECT oid FROM pg_namespace WHERE nspname = $1", d.Get("schema_name").(string)).Scan(&oid)
-
-	if err != nil {
-		return errwrap.Wrapf(fmt.Sprintf("Error reading oid for created external schema %s: {{err}}", d.Get("schema_name").(string)), err)
-	}
-
-	log.Printf("Created external schema with oid: %d", oid)
-
-	d.SetId(oid)
+	d.Set("schema_name", wrapper.Get("schema_name"))
+	d.Set("owner", wrapper.Get("owner"))
+	d.Set("database_name", block["database_name"])
+	d.Set("iam_role", block["iam_role"])
+	d.Set("policy", wrapper.Get("policy"))
 
-	readErr := readDataCatalogSchema(d, redshiftClient)
-
-	return readErr
+	return nil
 }
 
-func resourceDataCatalogSchemaRead(d *schema.ResourceData, meta interface{}) error {
-
-	redshiftClient := meta.(*Client).db
-
-	err := readDataCatalogSchema(d, redshiftClient)
-
-	return err
+func resourceDataCatalogSchemaExists(d *schema.ResourceData, meta interface{}) (bool, error) {
+	return resourceRedshiftExternalSchemaExists(d, meta)
 }
 
-func readDataCatalogSchema(d *schema.ResourceData, db *sql.DB) error {
-	var (
-		schemaName   string
-		owner        int
-		databaseName string
-		iamRole      string
-	)
-
-	err := db.QueryRow("SELECT nspname, nspowner, databasename, json_extract_path_text(esoptions, 'IAM_ROLE') FROM pg_namespace JOIN svv_external_schemas ON pg_namespace.oid = esoid WHERE pg_namespace.oid = $1", d.Id()).Scan(&schemaName, &owner, &databaseName, &iamRole)
+func resourceDataCatalogSchemaCreate(d *schema.ResourceData, meta interface{}) error {
+	wrapper := dataCatalogWrapperData(d)
 
-	if err != nil {
-		return errwrap.Wrapf(fmt.Sprintf("Error reading external schema information for oid %d: {{err}}", d.Id()), err)
+	if err := resourceRedshiftExternalSchemaCreate(wrapper, meta); err != nil {
+		return err
 	}
 
-	d.Set("schema_name", schemaName)
-	d.Set("owner", owner)
-	d.Set("database_name", databaseName)
-	d.Set("iam_role", iamRole)
-
-	return nil
+	d.SetId(wrapper.Id())
+	return syncDataCatalogFromWrapper(d, wrapper)
 }
 
-type ExecQueryer interface {
-	Exec(query string, args ...interface{}) (sql.Result, error)
-	Query(query string, args ...interface{}) (*sql.Rows, error)
-	QueryRow(query string, args ...interface{}) *sql.Row
-}
-
-
-func updateDataCatalogSchemaOwner(d *schema.ResourceData, q ExecQueryer) error {
-	var username = GetUsersnamesForUsesysid(q, []interface{}{d.Get("owner").(int)})
+func resourceDataCatalogSchemaRead(d *schema.ResourceData, meta interface{}) error {
+	wrapper := dataCatalogWrapperData(d)
 
-	if _, err := q.Exec("ALTER SCHEMA " + d.Get("schema_name").(string) + " OWNER TO " + username[0]); err != nil {
-		return errwrap.Wrapf(fmt.Sprintf("Error updating external schema %s owner to %s: {{err}}", d.Get("schema_name").(string), username[0]), err)
+	if err := resourceRedshiftExternalSchemaRead(wrapper, meta); err != nil {
+		return err
 	}
 
-	return nil
+	return syncDataCatalogFromWrapper(d, wrapper)
 }
 
 func resourceDataCatalogSchemaUpdate(d *schema.ResourceData, meta interface{}) error {
-
-	redshiftClient := meta.(*Client).db
-	tx, txErr := redshiftClient.Begin()
-	if txErr != nil {
-		panic(txErr)
-	}
-
-	if d.HasChange("schema_name") {
-
-		oldName, newName := d.GetChange("schema_name")
-		alterSchemaNameQuery := "ALTER SCHEMA " + oldName.(string) + " RENAME TO " + newName.(string)
-
-		if _, err := tx.Exec(alterSchemaNameQuery); err != nil {
-			return errwrap.Wrapf(fmt.Sprintf("Error renaming external schema %s to %s: {{err}}", oldName.(string), newName.(string)), err)
-		}
-	}
-
-	if d.HasChange("owner") {
-		if err := updateDataCatalogSchemaOwner(d, tx); err != nil {
-			return err
-		}
-	}
-
-	err := readDataCatalogSchema(d, redshiftClient)
-
-	if err != nil {
-		tx.Rollback()
-		return errwrap.Wrapf("Error performing rollback: {{err}}", err)
+	client := meta.(*Client)
+
+	// schema_name, owner and policy are named and typed identically on
+	// both resources, so the shared update logic can run directly against
+	// this resource's own ResourceData - database_name/iam_role are
+	// ForceNew on both and never reach Update.
+	if err := client.WithRetryTx(context.Background(), func(tx *sql.Tx) error {
+		return updateRedshiftExternalSchema(d, tx)
+	}); err != nil {
+		return err
 	}
 
-	tx.Commit()
-	return nil
+	return resourceDataCatalogSchemaRead(d, meta)
 }
 
 func resourceDataCatalogSchemaDelete(d *schema.ResourceData, meta interface{}) error {
-
-	client := meta.(*Client).db
-
-	dropSchemaQuery := "DROP SCHEMA " + d.Get("schema_name").(string)
-
-	if v, ok := d.GetOk("cascade_on_delete"); ok && v.(bool) {
-		dropSchemaQuery += " CASCADE "
-	}
-
-	_, err := client.Exec(dropSchemaQuery)
-
-	if err != nil {
-		return errwrap.Wrapf(fmt.Sprintf("Error dropping external schema %s: {{err}}", d.Get("schema_name").(string)), err)
-	}
-
-	return nil
+	return resourceRedshiftExternalSchemaDelete(d, meta)
 }
 
 func resourceDataCatalogSchemaImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
@@ -0,0 +1,339 @@
+package redshift
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bitzstein/terraform-provider-redshift/redshift/sqlutil"
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+const defaultQuota = "UNLIMITED"
+
+var quotaPattern = regexp.MustCompile(`(?i)^(\d+)\s*(MB|GB|TB)$`)
+
+func redshiftSchema() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceRedshiftSchemaCreate,
+		Read:   resourceRedshiftSchemaRead,
+		Update: resourceRedshiftSchemaUpdate,
+		Delete: resourceRedshiftSchemaDelete,
+		Exists: resourceRedshiftSchemaExists,
+		Importer: &schema.ResourceImporter{
+			State: resourceRedshiftSchemaImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The name of the schema",
+			},
+			"owner": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "The user name of the owner of the schema. Defaults to the user specified in the provider",
+			},
+			"if_not_exists": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Do not fail if the schema already exists",
+			},
+			"quota": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Default:          defaultQuota,
+				Description:      "The maximum amount of disk space the schema can use, e.g. '100 GB', or 'UNLIMITED'",
+				ValidateFunc:     validateSchemaQuota,
+				DiffSuppressFunc: suppressEquivalentQuota,
+			},
+			"cascade_on_delete": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Keyword that indicates to automatically drop all objects in the schema, such as tables and functions. By default it doesn't for your safety",
+				Default:     false,
+			},
+			"policy": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "Policy to apply to the schema, supersedes any existing grants",
+				Elem:        schemaPolicyElem(),
+			},
+		},
+	}
+}
+
+func validateSchemaQuota(v interface{}, key string) (warnings []string, errors []error) {
+	value := v.(string)
+	if strings.EqualFold(value, defaultQuota) || quotaPattern.MatchString(value) {
+		return nil, nil
+	}
+	return nil, []error{fmt.Errorf("%q must be 'UNLIMITED' or a number followed by MB, GB or TB, got: %s", key, value)}
+}
+
+func suppressEquivalentQuota(k, old, new string, d *schema.ResourceData) bool {
+	oldMB, oldErr := quotaToMB(old)
+	newMB, newErr := quotaToMB(new)
+	if oldErr != nil || newErr != nil {
+		return strings.EqualFold(old, new)
+	}
+	return oldMB == newMB
+}
+
+// quotaToMB converts a "<n> <unit>" or "UNLIMITED" quota string into the
+// number of megabytes Redshift expects on `CREATE/ALTER SCHEMA ... QUOTA`.
+// A returned value of 0 means UNLIMITED.
+func quotaToMB(quota string) (int64, error) {
+	if strings.EqualFold(quota, defaultQuota) {
+		return 0, nil
+	}
+
+	matches := quotaPattern.FindStringSubmatch(quota)
+	if matches == nil {
+		return 0, fmt.Errorf("invalid quota %q", quota)
+	}
+
+	amount, err := strconv.ParseInt(matches[1], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	switch strings.ToUpper(matches[2]) {
+	case "MB":
+		return amount, nil
+	case "GB":
+		return amount * 1024, nil
+	case "TB":
+		return amount * 1024 * 1024, nil
+	default:
+		return 0, fmt.Errorf("invalid quota unit in %q", quota)
+	}
+}
+
+// quotaFromMB is the inverse of quotaToMB, rendering the largest unit that
+// divides the value evenly so that Read doesn't perturb a config written in
+// GB/TB terms.
+func quotaFromMB(mb int64) string {
+	switch {
+	case mb <= 0:
+		return defaultQuota
+	case mb%(1024*1024) == 0:
+		return fmt.Sprintf("%d TB", mb/(1024*1024))
+	case mb%1024 == 0:
+		return fmt.Sprintf("%d GB", mb/1024)
+	default:
+		return fmt.Sprintf("%d MB", mb)
+	}
+}
+
+func resourceRedshiftSchemaExists(d *schema.ResourceData, meta interface{}) (b bool, e error) {
+	client := meta.(*Client).db
+
+	var name string
+
+	err := client.QueryRow("SELECT nspname FROM pg_namespace WHERE oid = $1", d.Id()).Scan(&name)
+	switch {
+	case err == sql.ErrNoRows:
+		return false, nil
+	case err != nil:
+		return false, errwrap.Wrapf(fmt.Sprintf("Error reading schema with oid %s: {{err}}", d.Id()), err)
+	}
+	return true, nil
+}
+
+func resourceRedshiftSchemaCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client)
+	redshiftClient := client.db
+
+	var buf bytes.Buffer
+	buf.WriteString("CREATE SCHEMA ")
+	if v, ok := d.GetOk("if_not_exists"); ok && v.(bool) {
+		buf.WriteString("IF NOT EXISTS ")
+	}
+	buf.WriteString(sqlutil.QuoteIdentifier(d.Get("name").(string)))
+
+	if v, ok := d.GetOk("owner"); ok {
+		buf.WriteString(" AUTHORIZATION ")
+		buf.WriteString(quoteRole(v.(string)))
+	}
+
+	if v, ok := d.GetOk("quota"); ok && !strings.EqualFold(v.(string), defaultQuota) {
+		mb, err := quotaToMB(v.(string))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&buf, " QUOTA %d MB", mb)
+	}
+
+	createStatement := buf.String()
+
+	log.Printf("Create schema statement: %s", createStatement)
+
+	if err := client.WithRetry(context.Background(), func(q ExecQueryer) error {
+		_, err := q.Exec(createStatement)
+		return err
+	}); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error creating schema %s: {{err}}", d.Get("name").(string)), err)
+	}
+
+	// The changes do not propagate instantly; poll pg_namespace until the
+	// new schema becomes visible instead of blindly sleeping for it.
+	oid, err := waitForSchemaVisible(context.Background(), redshiftClient, d.Get("name").(string), time.Now().Add(30*time.Second))
+	if err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error reading oid for created schema %s: {{err}}", d.Get("name").(string)), err)
+	}
+
+	d.SetId(oid)
+
+	if v, ok := d.GetOk("policy"); ok {
+		if err := applySchemaPolicies(redshiftClient, d.Get("name").(string), nil, expandSchemaPolicies(v)); err != nil {
+			return err
+		}
+	}
+
+	return readRedshiftSchema(d, redshiftClient)
+}
+
+func resourceRedshiftSchemaRead(d *schema.ResourceData, meta interface{}) error {
+	redshiftClient := meta.(*Client).db
+	return readRedshiftSchema(d, redshiftClient)
+}
+
+func readRedshiftSchema(d *schema.ResourceData, db *sql.DB) error {
+	var (
+		name  string
+		owner string
+	)
+
+	err := db.QueryRow("SELECT nspname, pg_get_userbyid(nspowner) FROM pg_namespace WHERE oid = $1", d.Id()).Scan(&name, &owner)
+	if err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error reading schema information for oid %s: {{err}}", d.Id()), err)
+	}
+
+	d.Set("name", name)
+	d.Set("owner", owner)
+
+	var quotaMB sql.NullInt64
+	err = db.QueryRow("SELECT quota FROM svv_schema_quota_state WHERE schema_name = $1", name).Scan(&quotaMB)
+	switch {
+	case err == sql.ErrNoRows:
+		d.Set("quota", defaultQuota)
+	case err != nil:
+		return errwrap.Wrapf(fmt.Sprintf("Error reading quota for schema %s: {{err}}", name), err)
+	default:
+		d.Set("quota", quotaFromMB(quotaMB.Int64))
+	}
+
+	policies, err := readSchemaPolicies(db, name)
+	if err != nil {
+		return err
+	}
+	d.Set("policy", flattenSchemaPolicies(policies))
+
+	return nil
+}
+
+func resourceRedshiftSchemaUpdate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client)
+
+	if err := client.WithRetryTx(context.Background(), func(tx *sql.Tx) error {
+		return updateRedshiftSchema(d, tx)
+	}); err != nil {
+		return err
+	}
+
+	return readRedshiftSchema(d, client.db)
+}
+
+func updateRedshiftSchema(d *schema.ResourceData, tx ExecQueryer) error {
+	if d.HasChange("name") {
+		oldName, newName := d.GetChange("name")
+
+		var buf bytes.Buffer
+		buf.WriteString("ALTER SCHEMA ")
+		buf.WriteString(sqlutil.QuoteIdentifier(oldName.(string)))
+		buf.WriteString(" RENAME TO ")
+		buf.WriteString(sqlutil.QuoteIdentifier(newName.(string)))
+
+		if _, err := tx.Exec(buf.String()); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("Error renaming schema %s to %s: {{err}}", oldName.(string), newName.(string)), err)
+		}
+	}
+
+	if d.HasChange("owner") {
+		var buf bytes.Buffer
+		buf.WriteString("ALTER SCHEMA ")
+		buf.WriteString(sqlutil.QuoteIdentifier(d.Get("name").(string)))
+		buf.WriteString(" OWNER TO ")
+		buf.WriteString(quoteRole(d.Get("owner").(string)))
+
+		if _, err := tx.Exec(buf.String()); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("Error changing owner of schema %s to %s: {{err}}", d.Get("name").(string), d.Get("owner").(string)), err)
+		}
+	}
+
+	if d.HasChange("quota") {
+		mb, err := quotaToMB(d.Get("quota").(string))
+		if err != nil {
+			return err
+		}
+
+		quotaClause := "UNLIMITED"
+		if mb > 0 {
+			quotaClause = fmt.Sprintf("%d MB", mb)
+		}
+
+		var buf bytes.Buffer
+		buf.WriteString("ALTER SCHEMA ")
+		buf.WriteString(sqlutil.QuoteIdentifier(d.Get("name").(string)))
+		buf.WriteString(" QUOTA ")
+		buf.WriteString(quotaClause)
+
+		if _, err := tx.Exec(buf.String()); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("Error changing quota of schema %s: {{err}}", d.Get("name").(string)), err)
+		}
+	}
+
+	if d.HasChange("policy") {
+		oldPolicies, newPolicies := d.GetChange("policy")
+		if err := applySchemaPolicies(tx, d.Get("name").(string), expandSchemaPolicies(oldPolicies), expandSchemaPolicies(newPolicies)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func resourceRedshiftSchemaDelete(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client).db
+
+	var buf bytes.Buffer
+	buf.WriteString("DROP SCHEMA ")
+	buf.WriteString(sqlutil.QuoteIdentifier(d.Get("name").(string)))
+	if v, ok := d.GetOk("cascade_on_delete"); ok && v.(bool) {
+		buf.WriteString(" CASCADE")
+	}
+
+	if _, err := client.Exec(buf.String()); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error dropping schema %s: {{err}}", d.Get("name").(string)), err)
+	}
+
+	return nil
+}
+
+func resourceRedshiftSchemaImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	if err := resourceRedshiftSchemaRead(d, meta); err != nil {
+		return nil, err
+	}
+	return []*schema.ResourceData{d}, nil
+}
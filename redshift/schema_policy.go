@@ -0,0 +1,246 @@
+package redshift
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/bitzstein/terraform-provider-redshift/redshift/sqlutil"
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// schemaPolicy represents a single `policy` block on a schema resource: the
+// set of USAGE/CREATE privileges (and whether they carry GRANT OPTION)
+// granted to a role on that schema.
+type schemaPolicy struct {
+	Role            string
+	Usage           bool
+	UsageWithGrant  bool
+	Create          bool
+	CreateWithGrant bool
+}
+
+func schemaPolicyElem() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"role": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The role this policy applies to. Use 'PUBLIC' to target all roles.",
+			},
+			"usage": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Whether the role can look up objects within the schema",
+			},
+			"usage_with_grant": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Whether the role can look up objects within the schema and grant the same privilege to others",
+			},
+			"create": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Whether the role can create objects within the schema",
+			},
+			"create_with_grant": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Whether the role can create objects within the schema and grant the same privilege to others",
+			},
+		},
+	}
+}
+
+// quoteRole quotes a role name as a SQL identifier, except for the special
+// PUBLIC pseudo-role, which Redshift does not accept quoted.
+func quoteRole(role string) string {
+	if strings.EqualFold(role, "PUBLIC") {
+		return "PUBLIC"
+	}
+	return sqlutil.QuoteIdentifier(role)
+}
+
+func expandSchemaPolicies(v interface{}) []schemaPolicy {
+	set := v.(*schema.Set)
+	policies := make([]schemaPolicy, 0, set.Len())
+
+	for _, raw := range set.List() {
+		p := raw.(map[string]interface{})
+		policies = append(policies, schemaPolicy{
+			Role:            p["role"].(string),
+			Usage:           p["usage"].(bool),
+			UsageWithGrant:  p["usage_with_grant"].(bool),
+			Create:          p["create"].(bool),
+			CreateWithGrant: p["create_with_grant"].(bool),
+		})
+	}
+
+	return policies
+}
+
+func flattenSchemaPolicies(policies []schemaPolicy) []interface{} {
+	result := make([]interface{}, 0, len(policies))
+
+	for _, p := range policies {
+		result = append(result, map[string]interface{}{
+			"role":              p.Role,
+			"usage":             p.Usage,
+			"usage_with_grant":  p.UsageWithGrant,
+			"create":            p.Create,
+			"create_with_grant": p.CreateWithGrant,
+		})
+	}
+
+	return result
+}
+
+// buildSchemaPolicyStatements diffs oldPolicies against newPolicies and
+// returns the minimal ordered sequence of GRANT/REVOKE statements needed to
+// bring the schema's privileges from the old state to the new one. Revokes
+// are emitted before grants so that a role transitioning between "with
+// grant" and plain ends up with exactly the requested privilege.
+func buildSchemaPolicyStatements(schemaName string, oldPolicies, newPolicies []schemaPolicy) []string {
+	quotedSchema := sqlutil.QuoteIdentifier(schemaName)
+
+	oldByRole := make(map[string]schemaPolicy, len(oldPolicies))
+	for _, p := range oldPolicies {
+		oldByRole[strings.ToLower(p.Role)] = p
+	}
+	newByRole := make(map[string]schemaPolicy, len(newPolicies))
+	for _, p := range newPolicies {
+		newByRole[strings.ToLower(p.Role)] = p
+	}
+
+	var revokes, grants []string
+
+	for key, old := range oldByRole {
+		new, stillPresent := newByRole[key]
+		if !stillPresent {
+			new = schemaPolicy{Role: old.Role}
+		}
+		revokes = append(revokes, privilegeStatements(quotedSchema, old.Role, "USAGE", old.Usage, old.UsageWithGrant, new.Usage, new.UsageWithGrant, true)...)
+		revokes = append(revokes, privilegeStatements(quotedSchema, old.Role, "CREATE", old.Create, old.CreateWithGrant, new.Create, new.CreateWithGrant, true)...)
+	}
+
+	for key, new := range newByRole {
+		old, existed := oldByRole[key]
+		if !existed {
+			old = schemaPolicy{Role: new.Role}
+		}
+		grants = append(grants, privilegeStatements(quotedSchema, new.Role, "USAGE", old.Usage, old.UsageWithGrant, new.Usage, new.UsageWithGrant, false)...)
+		grants = append(grants, privilegeStatements(quotedSchema, new.Role, "CREATE", old.Create, old.CreateWithGrant, new.Create, new.CreateWithGrant, false)...)
+	}
+
+	// Leaves the result nil when there is nothing to do.
+	var statements []string
+	statements = append(statements, revokes...)
+	statements = append(statements, grants...)
+	return statements
+}
+
+// privilegeStatements computes the statement(s) needed for a single
+// privilege (USAGE or CREATE) on a single role. It is called twice per role
+// transition: once to collect the revoke half, once to collect the grant
+// half, selected via the wantRevokes flag.
+func privilegeStatements(quotedSchema, role, privilege string, hadPlain, hadGrant, wantPlain, wantGrant bool, wantRevokes bool) []string {
+	quotedRole := quoteRole(role)
+	var buf bytes.Buffer
+	var statements []string
+
+	switch {
+	case (hadPlain || hadGrant) && !wantPlain && !wantGrant:
+		// Privilege removed entirely.
+		if wantRevokes {
+			fmt.Fprintf(&buf, "REVOKE %s ON SCHEMA %s FROM %s", privilege, quotedSchema, quotedRole)
+			statements = append(statements, buf.String())
+		}
+	case hadGrant && wantPlain && !wantGrant:
+		// Downgrade from WITH GRANT OPTION to plain: drop only the grant option.
+		if wantRevokes {
+			fmt.Fprintf(&buf, "REVOKE GRANT OPTION FOR %s ON SCHEMA %s FROM %s", privilege, quotedSchema, quotedRole)
+			statements = append(statements, buf.String())
+		}
+	case !hadGrant && wantGrant:
+		// New grant, or upgrade from plain to WITH GRANT OPTION.
+		if !wantRevokes {
+			fmt.Fprintf(&buf, "GRANT %s ON SCHEMA %s TO %s WITH GRANT OPTION", privilege, quotedSchema, quotedRole)
+			statements = append(statements, buf.String())
+		}
+	case !hadPlain && !hadGrant && wantPlain:
+		if !wantRevokes {
+			fmt.Fprintf(&buf, "GRANT %s ON SCHEMA %s TO %s", privilege, quotedSchema, quotedRole)
+			statements = append(statements, buf.String())
+		}
+	}
+
+	return statements
+}
+
+func applySchemaPolicies(q ExecQueryer, schemaName string, oldPolicies, newPolicies []schemaPolicy) error {
+	for _, stmt := range buildSchemaPolicyStatements(schemaName, oldPolicies, newPolicies) {
+		if _, err := q.Exec(stmt); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("Error applying schema policy statement %q: {{err}}", stmt), err)
+		}
+	}
+	return nil
+}
+
+// readSchemaPolicies reconstructs the set of USAGE/CREATE grants on a schema
+// by exploding its pg_namespace.nspacl entries. Every entry is returned, the
+// owner's own included; a schema whose nspacl is NULL yields no policies.
+func readSchemaPolicies(db *sql.DB, schemaName string) ([]schemaPolicy, error) {
+	rows, err := db.Query(`
+		SELECT
+			COALESCE(pg_get_userbyid(acl.grantee), 'PUBLIC') AS role,
+			acl.privilege_type,
+			acl.is_grantable
+		FROM pg_namespace n, aclexplode(n.nspacl) AS acl
+		WHERE n.nspname = $1 AND acl.privilege_type IN ('USAGE', 'CREATE')
+	`, schemaName)
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("Error reading policies for schema %s: {{err}}", schemaName), err)
+	}
+	defer rows.Close()
+
+	byRole := make(map[string]*schemaPolicy)
+	var order []string
+
+	for rows.Next() {
+		var role, privilege string
+		var grantable bool
+
+		if err := rows.Scan(&role, &privilege, &grantable); err != nil {
+			return nil, errwrap.Wrapf(fmt.Sprintf("Error scanning policy row for schema %s: {{err}}", schemaName), err)
+		}
+
+		p, ok := byRole[role]
+		if !ok {
+			p = &schemaPolicy{Role: role}
+			byRole[role] = p
+			order = append(order, role)
+		}
+
+		switch privilege {
+		case "USAGE":
+			p.Usage = true
+			p.UsageWithGrant = grantable
+		case "CREATE":
+			p.Create = true
+			p.CreateWithGrant = grantable
+		}
+	}
+
+	policies := make([]schemaPolicy, 0, len(order))
+	for _, role := range order {
+		policies = append(policies, *byRole[role])
+	}
+
+	return policies, rows.Err()
+}
@@ -0,0 +1,79 @@
+package redshift
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildSchemaPolicyStatements(t *testing.T) {
+	cases := []struct {
+		name string
+		old  []schemaPolicy
+		new  []schemaPolicy
+		want []string
+	}{
+		{
+			name: "new role granted",
+			old:  nil,
+			new: []schemaPolicy{
+				{Role: "alice", Usage: true},
+			},
+			want: []string{
+				`GRANT USAGE ON SCHEMA "myschema" TO "alice"`,
+			},
+		},
+		{
+			name: "role fully removed",
+			old: []schemaPolicy{
+				{Role: "alice", Usage: true},
+			},
+			new: nil,
+			want: []string{
+				`REVOKE USAGE ON SCHEMA "myschema" FROM "alice"`,
+			},
+		},
+		{
+			name: "plain to with-grant upgrade",
+			old: []schemaPolicy{
+				{Role: "alice", Usage: true},
+			},
+			new: []schemaPolicy{
+				{Role: "alice", Usage: true, UsageWithGrant: true},
+			},
+			want: []string{
+				`GRANT USAGE ON SCHEMA "myschema" TO "alice" WITH GRANT OPTION`,
+			},
+		},
+		{
+			name: "with-grant to plain downgrade",
+			old: []schemaPolicy{
+				{Role: "alice", Usage: true, UsageWithGrant: true},
+			},
+			new: []schemaPolicy{
+				{Role: "alice", Usage: true},
+			},
+			want: []string{
+				`REVOKE GRANT OPTION FOR USAGE ON SCHEMA "myschema" FROM "alice"`,
+			},
+		},
+		{
+			name: "no-op diff",
+			old: []schemaPolicy{
+				{Role: "alice", Usage: true},
+			},
+			new: []schemaPolicy{
+				{Role: "alice", Usage: true},
+			},
+			want: nil,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := buildSchemaPolicyStatements("myschema", c.old, c.new)
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("buildSchemaPolicyStatements(%q, %v, %v) = %v, want %v", "myschema", c.old, c.new, got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+// Package sqlutil provides small helpers for safely composing SQL
+// statements that interpolate user-supplied identifiers and literals, for
+// the resources that cannot use placeholder arguments (e.g. DDL statements,
+// where Redshift does not accept bind parameters for object names).
+package sqlutil
+
+import "strings"
+
+// QuoteIdentifier wraps a SQL identifier (schema name, role name, column
+// name, ...) in double quotes, escaping any embedded double quote by
+// doubling it. It mirrors pq.QuoteIdentifier and should be used for every
+// identifier interpolated into a statement built outside of the database/sql
+// placeholder mechanism.
+func QuoteIdentifier(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
+
+// QuoteLiteral wraps a SQL string literal in single quotes, doubling any
+// embedded single quote. Under the default standard_conforming_strings=on,
+// a plain '...' literal treats backslashes as ordinary characters, so a
+// literal containing one is instead emitted as an E'...' escape-string
+// literal with its backslashes doubled, mirroring pq.QuoteLiteral.
+func QuoteLiteral(literal string) string {
+	hasBackslash := strings.Contains(literal, `\`)
+
+	if hasBackslash {
+		literal = strings.Replace(literal, `\`, `\\`, -1)
+	}
+	literal = strings.Replace(literal, `'`, `''`, -1)
+
+	if hasBackslash {
+		return `E'` + literal + `'`
+	}
+	return "'" + literal + "'"
+}
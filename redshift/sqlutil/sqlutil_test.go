@@ -0,0 +1,46 @@
+package sqlutil
+
+import "testing"
+
+func TestQuoteIdentifier(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain lowercase", "my_schema", `"my_schema"`},
+		{"uppercase", "MySchema", `"MySchema"`},
+		{"embedded space", "my schema", `"my schema"`},
+		{"embedded quote", `my"schema`, `"my""schema"`},
+		{"reserved word", "select", `"select"`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := QuoteIdentifier(c.in); got != c.want {
+				t.Errorf("QuoteIdentifier(%q) = %q, want %q", c.in, got, c.want)
+			}
+		})
+	}
+}
+
+func TestQuoteLiteral(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "arn:aws:iam::123456789012:role/MyRole", `'arn:aws:iam::123456789012:role/MyRole'`},
+		{"apostrophe", "O'Brien", `'O''Brien'`},
+		{"backslash", `C:\path`, `E'C:\\path'`},
+		{"both", `O'Brien\Data`, `E'O''Brien\\Data'`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := QuoteLiteral(c.in); got != c.want {
+				t.Errorf("QuoteLiteral(%q) = %q, want %q", c.in, got, c.want)
+			}
+		})
+	}
+}